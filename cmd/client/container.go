@@ -1,13 +1,21 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/urfave/cli"
 	"golang.org/x/net/context"
+	"k8s.io/apimachinery/pkg/util/term"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
 	pb "k8s.io/kubernetes/pkg/kubelet/api/v1alpha1/runtime"
 )
 
@@ -20,8 +28,11 @@ var containerCommand = cli.Command{
 		stopContainerCommand,
 		removeContainerCommand,
 		containerStatusCommand,
+		inspectContainerCommand,
 		listContainersCommand,
 		execSyncCommand,
+		execCommand,
+		attachCommand,
 	},
 }
 
@@ -204,6 +215,33 @@ var containerStatusCommand = cli.Command{
 	},
 }
 
+var inspectContainerCommand = cli.Command{
+	Name:  "inspect",
+	Usage: "get the status of a container in a machine-readable format",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "id",
+			Value: "",
+			Usage: "id of the container",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		// Set up a connection to the server.
+		conn, err := getClientConnection(context)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+		client := pb.NewRuntimeServiceClient(conn)
+
+		err = InspectContainer(client, context.String("id"))
+		if err != nil {
+			return fmt.Errorf("getting the status of the container failed: %v", err)
+		}
+		return nil
+	},
+}
+
 var execSyncCommand = cli.Command{
 	Name:  "execsync",
 	Usage: "exec a command synchronously in a container",
@@ -236,6 +274,74 @@ var execSyncCommand = cli.Command{
 	},
 }
 
+var execCommand = cli.Command{
+	Name:      "exec",
+	Usage:     "exec a command in a container",
+	ArgsUsage: "CONTAINER-ID COMMAND [ARG...]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "tty, t",
+			Usage: "allocate a pseudo-TTY",
+		},
+		cli.BoolFlag{
+			Name:  "stdin, i",
+			Usage: "keep stdin open",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) < 2 {
+			return fmt.Errorf("exec requires a container id and a command")
+		}
+
+		// Set up a connection to the server.
+		conn, err := getClientConnection(context)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+		client := pb.NewRuntimeServiceClient(conn)
+
+		err = Exec(client, args[0], args[1:], context.Bool("tty"), context.Bool("stdin"))
+		if err != nil {
+			return fmt.Errorf("execing command in container failed: %v", err)
+		}
+		return nil
+	},
+}
+
+var attachCommand = cli.Command{
+	Name:      "attach",
+	Usage:     "attach to a running container",
+	ArgsUsage: "CONTAINER-ID",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "stdin, i",
+			Usage: "keep stdin open",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		id := context.Args().First()
+		if id == "" {
+			return fmt.Errorf("ID cannot be empty")
+		}
+
+		// Set up a connection to the server.
+		conn, err := getClientConnection(context)
+		if err != nil {
+			return fmt.Errorf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+		client := pb.NewRuntimeServiceClient(conn)
+
+		err = Attach(client, id, context.Bool("stdin"))
+		if err != nil {
+			return fmt.Errorf("attaching to container failed: %v", err)
+		}
+		return nil
+	},
+}
+
 type listOptions struct {
 	// id of the container
 	id string
@@ -247,6 +353,12 @@ type listOptions struct {
 	quiet bool
 	// labels are selectors for the container
 	labels map[string]string
+	// latest shows only the most recently created container
+	latest bool
+	// last shows only the last n created containers
+	last int
+	// noTrunc disables truncation of the container ID
+	noTrunc bool
 }
 
 var listContainersCommand = cli.Command{
@@ -276,6 +388,18 @@ var listContainersCommand = cli.Command{
 			Name:  "label",
 			Usage: "filter by key=value label",
 		},
+		cli.BoolFlag{
+			Name:  "latest, l",
+			Usage: "show only the most recently created container",
+		},
+		cli.IntFlag{
+			Name:  "last",
+			Usage: "show only the last n created containers",
+		},
+		cli.BoolFlag{
+			Name:  "no-trunc",
+			Usage: "show the full container ID instead of truncating it to 12 characters",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		// Set up a connection to the server.
@@ -286,11 +410,14 @@ var listContainersCommand = cli.Command{
 		defer conn.Close()
 		client := pb.NewRuntimeServiceClient(conn)
 		opts := listOptions{
-			id:     context.String("id"),
-			podID:  context.String("pod"),
-			state:  context.String("state"),
-			quiet:  context.Bool("quiet"),
-			labels: make(map[string]string),
+			id:      context.String("id"),
+			podID:   context.String("pod"),
+			state:   context.String("state"),
+			quiet:   context.Bool("quiet"),
+			labels:  make(map[string]string),
+			latest:  context.Bool("latest"),
+			last:    context.Int("last"),
+			noTrunc: context.Bool("no-trunc"),
 		}
 
 		for _, l := range context.StringSlice("label") {
@@ -427,6 +554,28 @@ func ContainerStatus(client pb.RuntimeServiceClient, ID string) error {
 	return nil
 }
 
+// InspectContainer sends a ContainerStatusRequest to the server, and prints
+// the returned ContainerStatusResponse as pretty-printed JSON, giving a
+// stable machine-readable view of a container.
+func InspectContainer(client pb.RuntimeServiceClient, ID string) error {
+	if ID == "" {
+		return fmt.Errorf("ID cannot be empty")
+	}
+	r, err := client.ContainerStatus(context.Background(), &pb.ContainerStatusRequest{
+		ContainerId: &ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonBytes))
+	return nil
+}
+
 // ExecSync sends an ExecSyncRequest to the server, and parses
 // the returned ExecSyncResponse.
 func ExecSync(client pb.RuntimeServiceClient, ID string, cmd []string, timeout int64) error {
@@ -450,6 +599,84 @@ func ExecSync(client pb.RuntimeServiceClient, ID string, cmd []string, timeout i
 	return nil
 }
 
+// Exec sends an ExecRequest to the server to obtain a streaming URL, then
+// proxies the user's stdin/stdout/stderr (and TTY resize events, if tty is
+// set) to it over SPDY.
+func Exec(client pb.RuntimeServiceClient, ID string, cmd []string, tty, stdin bool) error {
+	if ID == "" {
+		return fmt.Errorf("ID cannot be empty")
+	}
+	r, err := client.Exec(context.Background(), &pb.ExecRequest{
+		ContainerId: &ID,
+		Cmd:         cmd,
+		Tty:         &tty,
+		Stdin:       &stdin,
+	})
+	if err != nil {
+		return err
+	}
+
+	return stream(*r.Url, tty, stdin)
+}
+
+// Attach sends an AttachRequest to the server to obtain a streaming URL,
+// then proxies the user's stdin/stdout/stderr to it over SPDY.
+func Attach(client pb.RuntimeServiceClient, ID string, stdin bool) error {
+	if ID == "" {
+		return fmt.Errorf("ID cannot be empty")
+	}
+	r, err := client.Attach(context.Background(), &pb.AttachRequest{
+		ContainerId: &ID,
+		Stdin:       &stdin,
+	})
+	if err != nil {
+		return err
+	}
+
+	return stream(*r.Url, false, stdin)
+}
+
+// stream connects to a streaming URL returned by Exec or Attach and proxies
+// the calling terminal to it, matching the ergonomics of "kubectl exec".
+func stream(rawURL string, tty, stdin bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid streaming URL %q: %v", rawURL, err)
+	}
+
+	executor, err := remotecommand.NewSPDYExecutor(&restclient.Config{}, "POST", u)
+	if err != nil {
+		return err
+	}
+
+	t := term.TTY{
+		In:  os.Stdin,
+		Out: os.Stdout,
+		Raw: tty,
+	}
+	if tty && !t.IsTerminalIn() {
+		return fmt.Errorf("input is not a terminal")
+	}
+
+	return t.Safe(func() error {
+		var sizeQueue remotecommand.TerminalSizeQueue
+		if tty {
+			sizeQueue = t.MonitorSize(t.GetSize())
+		}
+
+		streamOptions := remotecommand.StreamOptions{
+			Stdout:            os.Stdout,
+			Stderr:            os.Stderr,
+			Tty:               tty,
+			TerminalSizeQueue: sizeQueue,
+		}
+		if stdin {
+			streamOptions.Stdin = os.Stdin
+		}
+		return executor.Stream(streamOptions)
+	})
+}
+
 // ListContainers sends a ListContainerRequest to the server, and parses
 // the returned ListContainerResponse.
 func ListContainers(client pb.RuntimeServiceClient, opts listOptions) error {
@@ -485,12 +712,36 @@ func ListContainers(client pb.RuntimeServiceClient, opts listOptions) error {
 	if err != nil {
 		return err
 	}
-	for _, c := range r.GetContainers() {
+
+	containers := r.GetContainers()
+	sort.Slice(containers, func(i, j int) bool {
+		var iCreated, jCreated int64
+		if containers[i].CreatedAt != nil {
+			iCreated = *containers[i].CreatedAt
+		}
+		if containers[j].CreatedAt != nil {
+			jCreated = *containers[j].CreatedAt
+		}
+		return iCreated > jCreated
+	})
+
+	if opts.latest && len(containers) > 1 {
+		containers = containers[:1]
+	}
+	if opts.last > 0 && opts.last < len(containers) {
+		containers = containers[:opts.last]
+	}
+
+	for _, c := range containers {
+		id := *c.Id
 		if opts.quiet {
-			fmt.Println(*c.Id)
+			fmt.Println(id)
 			continue
 		}
-		fmt.Printf("ID: %s\n", *c.Id)
+		if !opts.noTrunc && len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Printf("ID: %s\n", id)
 		fmt.Printf("Pod: %s\n", *c.PodSandboxId)
 		if c.Metadata != nil {
 			if c.Metadata.Name != nil {
@@ -505,7 +756,7 @@ func ListContainers(client pb.RuntimeServiceClient, opts listOptions) error {
 		}
 		if c.CreatedAt != nil {
 			ctm := time.Unix(0, *c.CreatedAt)
-			fmt.Printf("Created: %v\n", ctm)
+			fmt.Printf("Created: %s ago\n", units.HumanDuration(time.Since(ctm)))
 		}
 		if c.Labels != nil {
 			fmt.Println("Labels:")
@@ -1,59 +1,142 @@
 package dirnotifier
 
 import (
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
-	"github.com/pkg/errors"
 )
 
 type DirectoryNotifier struct {
 	watcher          *fsnotify.Watcher
-	filesToNotifiers sync.Map
+	mu               sync.Mutex
+	filesToNotifiers map[string][]chan fsnotify.Event
 	opsToWatch       []fsnotify.Op
 	dir              string
+	recursive        bool
+	closed           chan struct{}
 }
 
-func New(dir string, opsToWatch ...fsnotify.Op) (*DirectoryNotifier, error) {
+// New creates a DirectoryNotifier watching dir for opsToWatch. If recursive
+// is true, every subdirectory present under dir at startup is watched as
+// well, and subdirectories created afterward are picked up automatically.
+func New(dir string, recursive bool, opsToWatch ...fsnotify.Op) (*DirectoryNotifier, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
 	}
 
 	dn := &DirectoryNotifier{
-		watcher:    watcher,
-		opsToWatch: opsToWatch,
-		dir:        dir,
+		watcher:          watcher,
+		filesToNotifiers: make(map[string][]chan fsnotify.Event),
+		opsToWatch:       opsToWatch,
+		dir:              dir,
+		recursive:        recursive,
+		closed:           make(chan struct{}),
 	}
 
-	go dn.initializeWatcher()
-
-	if err := watcher.Add(dir); err != nil {
+	if recursive {
+		err = dn.watchTree(dir)
+	} else {
+		err = watcher.Add(dir)
+	}
+	if err != nil {
+		watcher.Close()
 		return nil, err
 	}
+
+	go dn.initializeWatcher()
+
 	return dn, nil
 }
 
+// watchTree walks root and adds every directory found (including root
+// itself) to the watcher, so that pre-existing nested subdirectories are
+// covered, not just root.
+func (dn *DirectoryNotifier) watchTree(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return dn.watcher.Add(path)
+		}
+		return nil
+	})
+}
+
 func (dn *DirectoryNotifier) initializeWatcher() {
-	defer dn.watcher.Close()
+	defer close(dn.closed)
 	for event := range dn.watcher.Events {
-		for op := range dn.opsToWatch {
-			if event.Op&fsnotify.Op(op) == fsnotify.Op(op) {
-				if notifyChan, ok := dn.filesToNotifiers.LoadAndDelete(event.Name); ok {
-					close(notifyChan.(chan struct{}))
-					break
-				}
+		if dn.recursive && event.Op&fsnotify.Create == fsnotify.Create {
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				dn.watchTree(event.Name) // nolint: errcheck
 			}
 		}
+
+		if dn.matchesWatchedOp(event.Op) {
+			dn.notify(event)
+		}
 	}
 }
 
-func (dn *DirectoryNotifier) NotifierForFile(file string) (chan struct{}, error) {
-	c := make(chan struct{}, 1)
-	if _, ok := dn.filesToNotifiers.LoadOrStore(file, c); ok {
-		return nil, errors.Errorf("exec watcher already watching file %s", file)
+// matchesWatchedOp reports whether op contains any of the fsnotify.Op
+// values dn was configured to watch.
+func (dn *DirectoryNotifier) matchesWatchedOp(op fsnotify.Op) bool {
+	for _, watched := range dn.opsToWatch {
+		if op&watched == watched {
+			return true
+		}
 	}
-	return c, nil
+	return false
+}
+
+func (dn *DirectoryNotifier) notify(event fsnotify.Event) {
+	dn.mu.Lock()
+	notifiers := dn.filesToNotifiers[event.Name]
+	delete(dn.filesToNotifiers, event.Name)
+	dn.mu.Unlock()
+
+	for _, c := range notifiers {
+		c <- event
+		close(c)
+	}
+}
+
+// EventNotifierForFile returns a fresh channel that receives the matching
+// fsnotify.Event the next time one of opsToWatch is observed for file, and
+// is then closed. Multiple callers may watch the same file; each gets its
+// own channel.
+func (dn *DirectoryNotifier) EventNotifierForFile(file string) chan fsnotify.Event {
+	c := make(chan fsnotify.Event, 1)
+
+	dn.mu.Lock()
+	dn.filesToNotifiers[file] = append(dn.filesToNotifiers[file], c)
+	dn.mu.Unlock()
+
+	return c
+}
+
+// NotifierForFile is a compatibility shim over EventNotifierForFile for
+// callers that only need a signal that something happened to file, not the
+// fsnotify.Event itself.
+func (dn *DirectoryNotifier) NotifierForFile(file string) chan struct{} {
+	events := dn.EventNotifierForFile(file)
+	c := make(chan struct{})
+	go func() {
+		<-events
+		close(c)
+	}()
+	return c
+}
+
+// Close stops the notifier's watch loop and releases the underlying
+// fsnotify watcher, waiting for the loop to exit.
+func (dn *DirectoryNotifier) Close() error {
+	err := dn.watcher.Close()
+	<-dn.closed
+	return err
 }
 
 func (dn *DirectoryNotifier) Directory() string {
@@ -0,0 +1,181 @@
+package dirnotifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestMatchesWatchedOp(t *testing.T) {
+	tests := []struct {
+		name       string
+		opsToWatch []fsnotify.Op
+		eventOp    fsnotify.Op
+		want       bool
+	}{
+		{
+			name:       "single op match",
+			opsToWatch: []fsnotify.Op{fsnotify.Write},
+			eventOp:    fsnotify.Write,
+			want:       true,
+		},
+		{
+			name:       "single op no match",
+			opsToWatch: []fsnotify.Op{fsnotify.Write},
+			eventOp:    fsnotify.Remove,
+			want:       false,
+		},
+		{
+			name:       "second op in slice matches",
+			opsToWatch: []fsnotify.Op{fsnotify.Write, fsnotify.Remove},
+			eventOp:    fsnotify.Remove,
+			want:       true,
+		},
+		{
+			name:       "combined event op matches one watched op",
+			opsToWatch: []fsnotify.Op{fsnotify.Chmod},
+			eventOp:    fsnotify.Write | fsnotify.Chmod,
+			want:       true,
+		},
+		{
+			name:       "no ops to watch never matches",
+			opsToWatch: nil,
+			eventOp:    fsnotify.Write,
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dn := &DirectoryNotifier{opsToWatch: tt.opsToWatch}
+			if got := dn.matchesWatchedOp(tt.eventOp); got != tt.want {
+				t.Errorf("matchesWatchedOp(%v) with opsToWatch %v = %v, want %v", tt.eventOp, tt.opsToWatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyFansOutToAllWatchers(t *testing.T) {
+	dn := &DirectoryNotifier{
+		filesToNotifiers: make(map[string][]chan fsnotify.Event),
+	}
+
+	const file = "/tmp/conmon-exit"
+	event := fsnotify.Event{Name: file, Op: fsnotify.Write}
+
+	a := dn.EventNotifierForFile(file)
+	b := dn.EventNotifierForFile(file)
+	legacy := dn.NotifierForFile(file)
+
+	dn.notify(event)
+
+	select {
+	case got := <-a:
+		if got != event {
+			t.Errorf("channel a got %v, want %v", got, event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel a was not notified")
+	}
+
+	select {
+	case got := <-b:
+		if got != event {
+			t.Errorf("channel b got %v, want %v", got, event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel b was not notified")
+	}
+
+	select {
+	case <-legacy:
+	case <-time.After(time.Second):
+		t.Fatal("legacy NotifierForFile channel was not closed")
+	}
+
+	if _, ok := dn.filesToNotifiers[file]; ok {
+		t.Error("notifiers for file were not cleared after firing")
+	}
+}
+
+func TestNewRecursiveWatchesExistingNestedDirectories(t *testing.T) {
+	root, err := ioutil.TempDir("", "dirnotifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	dn, err := New(root, true, fsnotify.Create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dn.Close()
+
+	marker := filepath.Join(nested, "marker")
+	notifier := dn.NotifierForFile(marker)
+
+	if err := ioutil.WriteFile(marker, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-notifier:
+	case <-time.After(5 * time.Second):
+		t.Fatal("create event for file in pre-existing nested directory was never observed")
+	}
+}
+
+func TestCreateEventWatchesPreExistingChildrenOfNewDirectory(t *testing.T) {
+	root, err := ioutil.TempDir("", "dirnotifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	dn, err := New(root, true, fsnotify.Create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dn.Close()
+
+	// Create a new directory after the watcher has already started, with a
+	// nested subdirectory already populated underneath it by the time the
+	// watcher's Create handler gets around to walking it.
+	newDir := filepath.Join(root, "new")
+	newDirNotifier := dn.NotifierForFile(newDir)
+	nested := filepath.Join(newDir, "nested")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The watch loop walks newDir before it notifies for newDir's Create
+	// event, so once that notification arrives nested is being watched and
+	// the marker's Create event cannot be missed.
+	select {
+	case <-newDirNotifier:
+	case <-time.After(5 * time.Second):
+		t.Fatal("create event for the new directory was never observed")
+	}
+
+	marker := filepath.Join(nested, "marker")
+	notifier := dn.NotifierForFile(marker)
+
+	if err := ioutil.WriteFile(marker, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-notifier:
+	case <-time.After(5 * time.Second):
+		t.Fatal("create event for file under a directory created (with pre-existing nested children) after startup was never observed")
+	}
+}